@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// inlineDataMaxBytes is the largest attachment we'll send as base64 inline
+// data. Anything bigger goes through Gemini's Files API instead.
+const inlineDataMaxBytes = 15 * 1024 * 1024 // 15MB, mirrors Gemini's inline limit
+
+// parseMultipartChatRequest builds a ChatRequest from a multipart/form-data
+// upload: a "messages" field holding a JSON array of the same shape as the
+// plain JSON endpoint's "messages", plus zero or more "files" parts. Model,
+// ConversationID and Options are not read from the form and stay at their
+// zero values. Small files are attached
+// inline as base64; large ones are uploaded to Gemini's Files API and
+// attached by URI, appended to the last message (which must be from the
+// user, same as the JSON path).
+func parseMultipartChatRequest(r *http.Request, apiKey string) (ChatRequest, error) {
+	var req ChatRequest
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return req, fmt.Errorf("failed to parse multipart form: %v", err)
+	}
+
+	messagesJSON := r.FormValue("messages")
+	if messagesJSON == "" {
+		return req, fmt.Errorf("missing messages field")
+	}
+	if err := json.Unmarshal([]byte(messagesJSON), &req.Messages); err != nil {
+		return req, fmt.Errorf("invalid messages field: %v", err)
+	}
+	if len(req.Messages) == 0 {
+		return req, fmt.Errorf("no messages provided")
+	}
+
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		return req, nil
+	}
+
+	lastIdx := len(req.Messages) - 1
+	for _, fh := range files {
+		att, err := fileHeaderToAttachment(r.Context(), fh, apiKey)
+		if err != nil {
+			return req, fmt.Errorf("failed to process uploaded file %q: %v", fh.Filename, err)
+		}
+		req.Messages[lastIdx].Attachments = append(req.Messages[lastIdx].Attachments, att)
+	}
+
+	return req, nil
+}
+
+func fileHeaderToAttachment(ctx context.Context, fh *multipart.FileHeader, apiKey string) (Attachment, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	mimeType := fh.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	if len(data) <= inlineDataMaxBytes {
+		return Attachment{MimeType: mimeType, Data: base64.StdEncoding.EncodeToString(data)}, nil
+	}
+
+	uri, err := uploadFile(ctx, apiKey, mimeType, data)
+	if err != nil {
+		return Attachment{}, err
+	}
+	return Attachment{MimeType: mimeType, FileURI: uri}, nil
+}
+
+// uploadFile uploads data to Gemini's Files API and returns the resulting
+// file URI for use in an Attachment's FileURI field.
+func uploadFile(ctx context.Context, apiKey, mimeType string, data []byte) (string, error) {
+	apiURL := "https://generativelanguage.googleapis.com/upload/v1beta/files?key=" + apiKey
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", mimeType)
+	httpReq.Header.Set("X-Goog-Upload-Protocol", "raw")
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file to Gemini: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gemini file upload error (%d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var uploadResp struct {
+		File struct {
+			URI string `json:"uri"`
+		} `json:"file"`
+	}
+	if err := json.Unmarshal(bodyBytes, &uploadResp); err != nil {
+		return "", fmt.Errorf("failed to decode upload response: %v", err)
+	}
+	if uploadResp.File.URI == "" {
+		return "", fmt.Errorf("gemini file upload response missing file URI: %s", string(bodyBytes))
+	}
+
+	return uploadResp.File.URI, nil
+}
@@ -1,53 +1,80 @@
 package handler
 
 import (
-	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+
+	"ai-chat-project/embeddings"
+	"ai-chat-project/provider"
 )
 
+// embeddingModel is the Gemini model used to embed conversation turns for
+// the semantic-memory RAG subsystem. Swap for "embedding-001" if needed.
+// Semantic memory always goes through Gemini regardless of which provider
+// answers the chat request, since it's the only embedder wired up so far.
+const embeddingModel = "text-embedding-004"
+
+// memoryStore holds embedded conversation turns for the lifetime of this
+// process so later turns can retrieve relevant earlier context. It is
+// pluggable behind embeddings.Store so a SQLite/pgvector backend can replace
+// it without touching Handler.
+var memoryStore embeddings.Store = embeddings.NewMemoryStore()
+
 // --- Define the chat message structs ---
 type ChatMessage struct {
-	Sender string `json:"sender"`
-	Text   string `json:"text"`
+	Sender      string       `json:"sender"`
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is an image/file part of a ChatMessage. Exactly one of Data or
+// FileURI should be set: Data for small payloads sent inline, FileURI for
+// files already uploaded via Gemini's Files API (see uploadFile).
+type Attachment struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data,omitempty"`
+	FileURI  string `json:"fileUri,omitempty"`
 }
 type ChatRequest struct {
 	Messages []ChatMessage `json:"messages"`
+	// Model selects which provider answers the request, e.g.
+	// "gemini-2.5-flash", "claude-3-5-sonnet", or "gpt-4o". Defaults to Gemini.
+	Model string `json:"model,omitempty"`
+	// ConversationID scopes semantic memory (retrieveContext/rememberMessages)
+	// to a single conversation, so one caller's turns are never surfaced as
+	// context for another's. If empty, semantic memory is skipped entirely
+	// rather than falling back to a shared, unscoped store.
+	ConversationID string `json:"conversationId,omitempty"`
+	// Options configures generation parameters and safety settings for this
+	// request, falling back to provider defaults when unset.
+	Options *Options `json:"options,omitempty"`
 }
 type ChatResponse struct {
 	Reply string `json:"reply"`
+	// FinishReason is the provider's own stop reason (e.g. "STOP" or
+	// "SAFETY"), empty if it didn't report one. BlockReason is only set when
+	// the prompt itself was blocked (Gemini's promptFeedback.blockReason).
+	// Together they let the client explain an empty reply.
+	FinishReason string `json:"finishReason,omitempty"`
+	BlockReason  string `json:"blockReason,omitempty"`
 }
 
-// --- Structs for Gemini API ---
-type GeminiRequest struct {
-	Contents         []GeminiContent  `json:"contents"`
-	GenerationConfig GenerationConfig `json:"generationConfig"`
-}
-type GeminiContent struct {
-	Role  string       `json:"role,omitempty"`
-	Parts []GeminiPart `json:"parts"`
-}
-type GeminiPart struct {
-	Text string `json:"text"`
-}
-type GenerationConfig struct {
-	Temperature     float32  `json:"temperature"`
-	TopK            int      `json:"topK"`
-	TopP            float32  `json:"topP"`
-	MaxOutputTokens int      `json:"maxOutputTokens"`
-	StopSequences   []string `json:"stopSequences"`
-}
-type GeminiResponse struct {
-	Candidates []struct {
-		Content struct {
-			Parts []GeminiPart `json:"parts"`
-		} `json:"content"`
-	} `json:"candidates"`
-}
+// maxBodyBytes caps the size of an incoming request body (JSON or
+// multipart), overridable via the MAX_BODY_BYTES env var.
+const maxBodyBytes = 20 * 1024 * 1024 // 20MB
+
+// maxMessages caps how many turns a single ChatRequest may carry, as a
+// cheap proxy for total token count until per-model tokenization is wired up.
+const maxMessages = 200
 
 func Handler(w http.ResponseWriter, r *http.Request) {
 	// 1. Setup CORS
@@ -59,146 +86,284 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 2. Get Gemini API Key
+	// 2. Per-IP rate limit, ahead of any real work.
+	ip := clientIP(r)
+	if !rateLimiter.Allow(ip) {
+		log.Printf("WARN: rate limit exceeded for %s", ip)
+		writeError(w, http.StatusTooManyRequests, "rate_limited", "too many requests, slow down", 1)
+		return
+	}
+
+	// 3. Cap request body size before reading any of it.
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytesLimit())
+
+	// 4. Get Gemini API Key (always needed: semantic memory and file
+	// uploads go through Gemini regardless of the chat provider selected).
 	geminiKey := os.Getenv("GEMINI_API_KEY")
 	if geminiKey == "" {
 		log.Println("ERROR: GEMINI_API_KEY env var is NOT SET")
-		http.Error(w, "GEMINI_API_KEY env var is NOT SET", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "server_misconfigured", "GEMINI_API_KEY env var is NOT SET", 0)
 		return
 	}
 
 	// --- ALL DATABASE CODE IS REMOVED ---
 
-	// 3. Parse the request (the array of messages)
-	bodyBytes, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("ERROR: Could not read request body: %v\n", err)
-		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+	// 5. Parse the request: multipart/form-data for messages with file
+	// uploads, plain JSON otherwise.
+	var req ChatRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		var err error
+		req, err = parseMultipartChatRequest(r, geminiKey)
+		if err != nil {
+			if isBodyTooLargeErr(err) {
+				writeError(w, http.StatusRequestEntityTooLarge, "payload_too_large", "request body exceeds the size limit", 0)
+				return
+			}
+			log.Printf("ERROR: Could not parse multipart request: %v\n", err)
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error(), 0)
+			return
+		}
+	} else {
+		bodyBytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			if isBodyTooLargeErr(err) {
+				writeError(w, http.StatusRequestEntityTooLarge, "payload_too_large", "request body exceeds the size limit", 0)
+				return
+			}
+			log.Printf("ERROR: Could not read request body: %v\n", err)
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid request: %v", err), 0)
+			return
+		}
+
+		log.Printf("Received request body: %s", string(bodyBytes))
+
+		if err := json.Unmarshal(bodyBytes, &req); err != nil {
+			log.Printf("ERROR: Could not decode request body: %v, body: %s\n", err, string(bodyBytes))
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid request: %v", err), 0)
+			return
+		}
+
+		// Validate messages
+		if len(req.Messages) == 0 {
+			log.Printf("ERROR: No messages in request, raw body was: %s", string(bodyBytes))
+			writeError(w, http.StatusBadRequest, "invalid_request", "no messages provided", 0)
+			return
+		}
+	}
+	log.Printf("Parsed request with %d messages", len(req.Messages))
+
+	if len(req.Messages) > maxMessages {
+		writeError(w, http.StatusRequestEntityTooLarge, "payload_too_large", fmt.Sprintf("too many messages: %d exceeds limit of %d", len(req.Messages), maxMessages), 0)
 		return
 	}
 
-	log.Printf("Received request body: %s", string(bodyBytes))
+	// 6. Validate generation options, if any were supplied.
+	if err := validateOptions(req.Options); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error(), 0)
+		return
+	}
 
-	var req ChatRequest
-	if err := json.Unmarshal(bodyBytes, &req); err != nil {
-		log.Printf("ERROR: Could not decode request body: %v, body: %s\n", err, string(bodyBytes))
-		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+	// 7. Select the provider adaptor for this request's model.
+	client, err := selectProvider(req.Model, geminiKey)
+	if err != nil {
+		log.Printf("ERROR: %v\n", err)
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error(), 0)
 		return
 	}
 
-	// Validate messages
-	log.Printf("Parsed request with %d messages", len(req.Messages))
-	if len(req.Messages) == 0 {
-		log.Printf("ERROR: No messages in request, raw body was: %s", string(bodyBytes))
-		http.Error(w, "no messages provided", http.StatusBadRequest)
+	// 8. Retrieve relevant prior turns (semantic memory) and remember this
+	// turn's messages for future requests, both scoped to ConversationID.
+	ragContext, err := retrieveContext(r.Context(), req.ConversationID, req.Messages, geminiKey)
+	if err != nil {
+		log.Printf("WARN: semantic memory retrieval failed, continuing without context: %v", err)
+	}
+	rememberMessages(r.Context(), req.ConversationID, req.Messages, geminiKey)
+
+	sysInstruction := ragContext
+	if req.Options != nil && req.Options.SystemInstruction != "" {
+		sysInstruction = req.Options.SystemInstruction + "\n" + ragContext
+	}
+
+	completionReq := provider.CompletionRequest{
+		Messages:          toProviderMessages(req.Messages),
+		SystemInstruction: sysInstruction,
+		Options:           toProviderOptions(req.Options),
+	}
+
+	// 9. Streaming mode: client asked for SSE via header or query param.
+	if wantsStream(r) {
+		if err := streamToClient(r.Context(), w, client, completionReq); err != nil {
+			log.Printf("ERROR: Failed to stream response: %v\n", err)
+		}
 		return
 	}
 
-	// 4. Go API -> Gemini
-	aiReply, err := callGemini(req.Messages, geminiKey)
+	// 10. Go API -> provider
+	resp, err := client.Complete(r.Context(), completionReq)
 	if err != nil {
-		log.Printf("ERROR: Failed to call Gemini: %v\n", err)
-		// Return error in JSON format so frontend can read it
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ChatResponse{Reply: fmt.Sprintf("Error: %v", err)})
+		log.Printf("ERROR: Failed to call provider: %v\n", err)
+		writeUpstreamError(w, err)
 		return
 	}
 
-	// 5. Go API -> User UI
-	resp := ChatResponse{Reply: aiReply}
+	// 11. Go API -> User UI
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(ChatResponse{Reply: resp.Text, FinishReason: resp.FinishReason, BlockReason: resp.BlockReason})
 }
 
-// Helper function to call Google Gemini
-func callGemini(messages []ChatMessage, apiKey string) (string, error) {
-	apiURL := "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent?key=" + apiKey
+// maxBodyBytesLimit returns maxBodyBytes, overridden by MAX_BODY_BYTES if set.
+func maxBodyBytesLimit() int64 {
+	if v := os.Getenv("MAX_BODY_BYTES"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return maxBodyBytes
+}
 
-	// Convert chat history to Gemini format
-	var geminiContents []GeminiContent
+// isBodyTooLargeErr reports whether err came from http.MaxBytesReader's
+// limit being hit, which net/http doesn't expose as a sentinel error.
+func isBodyTooLargeErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "http: request body too large")
+}
 
-	// Loop through the actual chat history from the UI and convert it to Gemini's format
-	for _, msg := range messages {
-		// Skip empty messages
-		if msg.Text == "" {
-			continue
+// selectProvider picks a ChatCompletionClient based on the requested model's
+// prefix, mirroring the multi-provider dispatch pattern used by tools like
+// lmcli/one-api. An empty model defaults to Gemini.
+func selectProvider(model, geminiKey string) (provider.ChatCompletionClient, error) {
+	switch {
+	case model == "" || strings.HasPrefix(model, "gemini"):
+		return provider.NewGeminiClient(geminiKey), nil
+	case strings.HasPrefix(model, "claude"):
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY env var is NOT SET")
 		}
+		return provider.NewAnthropicClient(apiKey), nil
+	case strings.HasPrefix(model, "gpt"):
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY env var is NOT SET")
+		}
+		return provider.NewOpenAIClient(apiKey), nil
+	default:
+		return nil, fmt.Errorf("unknown model %q", model)
+	}
+}
 
-		var role string
+// toProviderMessages converts our ChatMessage history into the
+// provider-agnostic Message shape.
+func toProviderMessages(messages []ChatMessage) []provider.Message {
+	out := make([]provider.Message, len(messages))
+	for i, msg := range messages {
+		role := "assistant"
 		if msg.Sender == "user" {
 			role = "user"
-		} else {
-			role = "model" // Map our "bot" sender to the "model" role
 		}
 
-		geminiContents = append(geminiContents, GeminiContent{
-			Role:  role,
-			Parts: []GeminiPart{{Text: msg.Text}},
-		})
-	}
+		var attachments []provider.Attachment
+		for _, att := range msg.Attachments {
+			attachments = append(attachments, provider.Attachment{
+				MimeType: att.MimeType,
+				Data:     att.Data,
+				FileURI:  att.FileURI,
+			})
+		}
 
-	// Ensure we have at least one message (should be a user message)
-	if len(geminiContents) == 0 {
-		return "", fmt.Errorf("no valid messages to send")
+		out[i] = provider.Message{Role: role, Text: msg.Text, Attachments: attachments}
 	}
+	return out
+}
 
-	// Ensure the last message is from user (required by Gemini)
-	lastMsg := geminiContents[len(geminiContents)-1]
-	if lastMsg.Role != "user" {
-		return "", fmt.Errorf("last message must be from user")
+// wantsStream reports whether the client asked for an SSE response, either
+// via the standard Accept header or the `?stream=1` query param.
+func wantsStream(r *http.Request) bool {
+	if r.URL.Query().Get("stream") == "1" {
+		return true
 	}
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
 
-	reqBody := GeminiRequest{
-		Contents: geminiContents, // Pass the full conversation history
-		GenerationConfig: GenerationConfig{
-			Temperature:     1.0,
-			TopK:            40,
-			TopP:            0.95,
-			MaxOutputTokens: 8192,
-			StopSequences:   []string{},
-		},
+// retrieveContext embeds the latest user message and looks up the most
+// relevant prior turns in memoryStore scoped to conversationID, returning
+// them as a system instruction string the caller can attach to the
+// completion request. It returns an empty string (and nil error) if there's
+// nothing to retrieve yet, or if conversationID is empty (memory can't be
+// scoped safely without one, so it's skipped rather than shared).
+func retrieveContext(ctx context.Context, conversationID string, messages []ChatMessage, apiKey string) (string, error) {
+	if conversationID == "" || len(messages) == 0 {
+		return "", nil
+	}
+	lastMsg := messages[len(messages)-1]
+	if lastMsg.Text == "" {
+		return "", nil
 	}
 
-	reqBytes, err := json.Marshal(reqBody)
+	vectors, err := embeddings.EmbedTexts(ctx, apiKey, embeddingModel, []string{lastMsg.Text})
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %v", err)
+		return "", fmt.Errorf("failed to embed query: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(reqBytes))
+	const topK = 3
+	docs, err := memoryStore.TopK(ctx, conversationID, vectors[0], topK)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
+		return "", fmt.Errorf("failed to query memory store: %v", err)
+	}
+	if len(docs) == 0 {
+		return "", nil
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to make request to Gemini API: %v", err)
+	contextText := "Relevant context from earlier in the conversation:\n"
+	for _, d := range docs {
+		contextText += "- " + d.Text + "\n"
 	}
-	defer resp.Body.Close()
+	return contextText, nil
+}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %v", err)
+// rememberMessages embeds each message in this turn and stores it in
+// memoryStore, scoped to conversationID, for future retrieval. Each
+// document's ID is a hash of conversationID+text, so resending the same
+// turn (typical of clients that replay full history every request) upserts
+// rather than duplicating it. Failures are logged, not returned, since
+// memory is a best-effort enhancement and shouldn't fail the chat request.
+func rememberMessages(ctx context.Context, conversationID string, messages []ChatMessage, apiKey string) {
+	if conversationID == "" {
+		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Gemini API error response: %s", string(bodyBytes))
-		return "", fmt.Errorf("gemini API error (%d): %s", resp.StatusCode, string(bodyBytes))
+	var texts []string
+	for _, msg := range messages {
+		if msg.Text != "" {
+			texts = append(texts, msg.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return
 	}
 
-	var geminiResp GeminiResponse
-	if err := json.Unmarshal(bodyBytes, &geminiResp); err != nil {
-		log.Printf("Failed to decode Gemini response: %v, body: %s", err, string(bodyBytes))
-		return "", fmt.Errorf("failed to decode response: %v", err)
+	vectors, err := embeddings.EmbedTexts(ctx, apiKey, embeddingModel, texts)
+	if err != nil {
+		log.Printf("WARN: failed to embed messages for semantic memory: %v", err)
+		return
 	}
 
-	if len(geminiResp.Candidates) > 0 &&
-		len(geminiResp.Candidates[0].Content.Parts) > 0 {
-		return geminiResp.Candidates[0].Content.Parts[0].Text, nil
+	for i, text := range texts {
+		doc := embeddings.Document{
+			ID:             messageDocID(conversationID, text),
+			ConversationID: conversationID,
+			Text:           text,
+			Embedding:      vectors[i],
+		}
+		if err := memoryStore.Add(ctx, doc); err != nil {
+			log.Printf("WARN: failed to store embedding in memory store: %v", err)
+		}
 	}
+}
 
-	log.Printf("Empty or blocked response from Gemini: %+v", geminiResp)
-	return "I'm sorry, I couldn't process that response.", nil
+// messageDocID derives a stable document ID for a (conversationID, text)
+// pair, so re-embedding the same turn across requests overwrites rather than
+// duplicates its entry in the store.
+func messageDocID(conversationID, text string) string {
+	sum := sha256.Sum256([]byte(conversationID + "\x00" + text))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"ai-chat-project/embeddings"
+)
+
+// EmbedRequest is the body accepted by the /api/embed endpoint: free-form
+// documents the caller wants embedded and added to the semantic-memory store.
+// ConversationID scopes the stored documents the same way it does for the
+// chat Handler; if empty, the texts are embedded and returned but not stored.
+type EmbedRequest struct {
+	Texts          []string `json:"texts"`
+	ConversationID string   `json:"conversationId,omitempty"`
+}
+
+// EmbedResponse echoes back one Vector per input text, in order.
+type EmbedResponse struct {
+	Embeddings []embeddings.Vector `json:"embeddings"`
+}
+
+// maxEmbedTexts caps how many texts a single EmbedRequest may carry, mirroring
+// maxMessages' role for the chat Handler: a cheap bound on a billed upstream call.
+const maxEmbedTexts = 200
+
+// Embed handles POST /api/embed: it embeds the given texts via Gemini and,
+// when ConversationID is set, stores them in memoryStore for later retrieval
+// by the chat Handler. It's
+// hardened the same way Handler is, since it also fronts a billed Gemini
+// call and would otherwise be an easy way to route around that hardening.
+func Embed(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	ip := clientIP(r)
+	if !rateLimiter.Allow(ip) {
+		log.Printf("WARN: rate limit exceeded for %s", ip)
+		writeError(w, http.StatusTooManyRequests, "rate_limited", "too many requests, slow down", 1)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytesLimit())
+
+	geminiKey := os.Getenv("GEMINI_API_KEY")
+	if geminiKey == "" {
+		log.Println("ERROR: GEMINI_API_KEY env var is NOT SET")
+		writeError(w, http.StatusInternalServerError, "server_misconfigured", "GEMINI_API_KEY env var is NOT SET", 0)
+		return
+	}
+
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		if isBodyTooLargeErr(err) {
+			writeError(w, http.StatusRequestEntityTooLarge, "payload_too_large", "request body exceeds the size limit", 0)
+			return
+		}
+		log.Printf("ERROR: Could not read request body: %v\n", err)
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid request: %v", err), 0)
+		return
+	}
+
+	var req EmbedRequest
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		log.Printf("ERROR: Could not decode request body: %v, body: %s\n", err, string(bodyBytes))
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid request: %v", err), 0)
+		return
+	}
+
+	if len(req.Texts) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request", "no texts provided", 0)
+		return
+	}
+	if len(req.Texts) > maxEmbedTexts {
+		writeError(w, http.StatusRequestEntityTooLarge, "payload_too_large", fmt.Sprintf("too many texts: %d exceeds limit of %d", len(req.Texts), maxEmbedTexts), 0)
+		return
+	}
+
+	vectors, err := embeddings.EmbedTexts(r.Context(), geminiKey, embeddingModel, req.Texts)
+	if err != nil {
+		log.Printf("ERROR: Failed to embed texts: %v\n", err)
+		writeUpstreamError(w, err)
+		return
+	}
+
+	if req.ConversationID != "" {
+		for i, text := range req.Texts {
+			doc := embeddings.Document{
+				ID:             messageDocID(req.ConversationID, text),
+				ConversationID: req.ConversationID,
+				Text:           text,
+				Embedding:      vectors[i],
+			}
+			if err := memoryStore.Add(r.Context(), doc); err != nil {
+				log.Printf("WARN: failed to store embedding in memory store: %v", err)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(EmbedResponse{Embeddings: vectors})
+}
@@ -0,0 +1,142 @@
+// Package embeddings provides text embedding via Gemini's batchEmbedContents
+// action plus a small pluggable vector store for semantic-memory retrieval.
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+)
+
+// Vector is a single embedding, e.g. the 768-dim output of text-embedding-004.
+type Vector []float32
+
+// Document is a piece of text together with its embedding, as stored in a
+// Store. ConversationID scopes it to a single conversation so Add/TopK never
+// mix context across conversations; ID identifies it within that scope
+// (typically a hash of ConversationID+Text) so a store can dedupe repeat
+// inserts of the same turn.
+type Document struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversationId"`
+	Text           string `json:"text"`
+	Embedding      Vector `json:"embedding"`
+}
+
+// Store is the interface a vector index must satisfy so that the in-memory
+// implementation here can later be swapped for a SQLite/pgvector-backed one.
+// Every call is scoped to a single conversationId so one conversation's
+// documents are never returned for another's query.
+type Store interface {
+	Add(ctx context.Context, doc Document) error
+	TopK(ctx context.Context, conversationID string, query Vector, k int) ([]Document, error)
+}
+
+// --- Gemini embedContent / batchEmbedContents wire format ---
+
+type embedContentRequest struct {
+	Model   string      `json:"model"`
+	Content geminiParts `json:"content"`
+}
+
+type geminiParts struct {
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type batchEmbedContentsRequest struct {
+	Requests []embedContentRequest `json:"requests"`
+}
+
+type batchEmbedContentsResponse struct {
+	Embeddings []struct {
+		Values []float32 `json:"values"`
+	} `json:"embeddings"`
+}
+
+// EmbedTexts calls Gemini's batchEmbedContents action for the given model
+// (e.g. "text-embedding-004" or "embedding-001") and returns one Vector per
+// input text, in the same order.
+func EmbedTexts(ctx context.Context, apiKey, model string, texts []string) ([]Vector, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	apiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:batchEmbedContents?key=%s", model, apiKey)
+
+	reqBody := batchEmbedContentsRequest{}
+	for _, t := range texts {
+		reqBody.Requests = append(reqBody.Requests, embedContentRequest{
+			Model:   "models/" + model,
+			Content: geminiParts{Parts: []geminiPart{{Text: t}}},
+		})
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embed request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embed request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Gemini embeddings API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embed response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gemini embeddings API error (%d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var embedResp batchEmbedContentsResponse
+	if err := json.Unmarshal(bodyBytes, &embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode embed response: %v", err)
+	}
+
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini embeddings API returned %d embeddings for %d texts", len(embedResp.Embeddings), len(texts))
+	}
+
+	vectors := make([]Vector, len(embedResp.Embeddings))
+	for i, e := range embedResp.Embeddings {
+		vectors[i] = Vector(e.Values)
+	}
+	return vectors, nil
+}
+
+// CosineSimilarity returns the cosine similarity between two vectors of equal
+// length, or 0 if either vector is empty.
+func CosineSimilarity(a, b Vector) float32 {
+	if len(a) == 0 || len(b) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float32
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / float32(math.Sqrt(float64(normA))*math.Sqrt(float64(normB)))
+}
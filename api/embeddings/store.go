@@ -0,0 +1,71 @@
+package embeddings
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a simple in-process Store, good enough for a single
+// serverless invocation's conversation history. A future SQLite/pgvector
+// backend can implement the same Store interface as a drop-in replacement.
+// Documents are keyed by (ConversationID, ID) so Add is idempotent for a
+// repeat insert of the same turn, and TopK never mixes documents across
+// conversations.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	docs map[string]map[string]Document // conversationID -> docID -> Document
+}
+
+// NewMemoryStore returns an empty in-memory vector store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{docs: make(map[string]map[string]Document)}
+}
+
+// Add stores doc under its ConversationID, overwriting any existing document
+// with the same ID so re-embedding an already-seen turn is a no-op rather
+// than a duplicate entry.
+func (s *MemoryStore) Add(ctx context.Context, doc Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byID, ok := s.docs[doc.ConversationID]
+	if !ok {
+		byID = make(map[string]Document)
+		s.docs[doc.ConversationID] = byID
+	}
+	byID[doc.ID] = doc
+	return nil
+}
+
+// TopK returns the k documents scoped to conversationID whose embeddings are
+// most similar to query, ranked by cosine similarity, highest first.
+func (s *MemoryStore) TopK(ctx context.Context, conversationID string, query Vector, k int) ([]Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type scored struct {
+		doc   Document
+		score float32
+	}
+
+	byID := s.docs[conversationID]
+	scoredDocs := make([]scored, 0, len(byID))
+	for _, d := range byID {
+		scoredDocs = append(scoredDocs, scored{doc: d, score: CosineSimilarity(query, d.Embedding)})
+	}
+
+	sort.Slice(scoredDocs, func(i, j int) bool {
+		return scoredDocs[i].score > scoredDocs[j].score
+	})
+
+	if k > len(scoredDocs) {
+		k = len(scoredDocs)
+	}
+
+	top := make([]Document, k)
+	for i := 0; i < k; i++ {
+		top[i] = scoredDocs[i].doc
+	}
+	return top, nil
+}
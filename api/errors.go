@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"ai-chat-project/provider"
+)
+
+// ErrorResponse is the single structured error envelope returned by Handler
+// for every failure path, in place of the old mix of http.Error and ad-hoc
+// JSON bodies.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retry_after,omitempty"` // seconds, when set
+}
+
+// writeError writes a structured JSON error with the given status code. If
+// retryAfterSeconds is > 0, it's also surfaced as a Retry-After header so
+// well-behaved clients can back off without parsing the body.
+func writeError(w http.ResponseWriter, status int, code, message string, retryAfterSeconds int) {
+	if retryAfterSeconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: ErrorDetail{Code: code, Message: message, RetryAfter: retryAfterSeconds},
+	})
+}
+
+// writeUpstreamError inspects an error returned by a provider.ChatCompletionClient
+// and writes the appropriate structured response: a 400 for a malformed
+// request caught before any call left this process, the provider's own 429
+// passed through with its Retry-After hint, or a 502 for anything else.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	var invalidErr *provider.InvalidRequestError
+	if errors.As(err, &invalidErr) {
+		writeError(w, http.StatusBadRequest, "invalid_request", invalidErr.Message, 0)
+		return
+	}
+
+	var upstreamErr *provider.UpstreamError
+	if errors.As(err, &upstreamErr) && upstreamErr.StatusCode == http.StatusTooManyRequests {
+		retryAfter := upstreamErr.RetryAfter
+		if retryAfter == 0 {
+			retryAfter = 1
+		}
+		writeError(w, http.StatusTooManyRequests, "rate_limited", "upstream provider is rate limiting requests", retryAfter)
+		return
+	}
+	writeError(w, http.StatusBadGateway, "upstream_error", fmt.Sprintf("upstream provider error: %v", err), 0)
+}
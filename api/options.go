@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"fmt"
+
+	"ai-chat-project/provider"
+)
+
+// Options carries the optional, per-request generation knobs a client may
+// set on a ChatRequest. Any field left nil/empty falls back to the
+// provider's own default (see provider.defaultGenConfig for Gemini's).
+type Options struct {
+	Temperature       *float32        `json:"temperature,omitempty"`
+	TopK              *int            `json:"topK,omitempty"`
+	TopP              *float32        `json:"topP,omitempty"`
+	MaxOutputTokens   *int            `json:"maxOutputTokens,omitempty"`
+	StopSequences     []string        `json:"stopSequences,omitempty"`
+	SafetySettings    []SafetySetting `json:"safetySettings,omitempty"`
+	SystemInstruction string          `json:"systemInstruction,omitempty"`
+}
+
+// SafetySetting is one Gemini harm-category/threshold pair, e.g.
+// {"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_ONLY_HIGH"}.
+// Ignored by providers other than Gemini.
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// maxStopSequences mirrors the limit most providers enforce on their own
+// stop-sequence arrays.
+const maxStopSequences = 4
+
+// validHarmCategories and validThresholds are Gemini's documented enum
+// values as of the 2.5 Flash API; other providers don't use SafetySettings
+// at all, so there's nothing to validate for them.
+var validHarmCategories = map[string]bool{
+	"HARM_CATEGORY_HARASSMENT":        true,
+	"HARM_CATEGORY_HATE_SPEECH":       true,
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT": true,
+	"HARM_CATEGORY_DANGEROUS_CONTENT": true,
+	"HARM_CATEGORY_CIVIC_INTEGRITY":   true,
+}
+
+var validThresholds = map[string]bool{
+	"BLOCK_NONE":             true,
+	"BLOCK_ONLY_HIGH":        true,
+	"BLOCK_MEDIUM_AND_ABOVE": true,
+	"BLOCK_LOW_AND_ABOVE":    true,
+}
+
+// validateOptions checks the caller-supplied Options against sane ranges
+// before they're forwarded to a provider, so a bad value surfaces as a 400
+// instead of an opaque upstream error.
+func validateOptions(opts *Options) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.Temperature != nil && (*opts.Temperature < 0 || *opts.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2, got %v", *opts.Temperature)
+	}
+	if opts.TopK != nil && *opts.TopK < 1 {
+		return fmt.Errorf("topK must be >= 1, got %v", *opts.TopK)
+	}
+	if opts.TopP != nil && (*opts.TopP < 0 || *opts.TopP > 1) {
+		return fmt.Errorf("topP must be between 0 and 1, got %v", *opts.TopP)
+	}
+	if opts.MaxOutputTokens != nil && (*opts.MaxOutputTokens < 1 || *opts.MaxOutputTokens > 8192) {
+		return fmt.Errorf("maxOutputTokens must be between 1 and 8192, got %v", *opts.MaxOutputTokens)
+	}
+	if len(opts.StopSequences) > maxStopSequences {
+		return fmt.Errorf("stopSequences accepts at most %d entries, got %d", maxStopSequences, len(opts.StopSequences))
+	}
+	for _, s := range opts.SafetySettings {
+		if !validHarmCategories[s.Category] {
+			return fmt.Errorf("unknown safety category %q", s.Category)
+		}
+		if !validThresholds[s.Threshold] {
+			return fmt.Errorf("unknown safety threshold %q", s.Threshold)
+		}
+	}
+	return nil
+}
+
+// toProviderOptions converts the handler-level Options into the
+// provider-agnostic GenerationOptions, passing nil fields through untouched
+// so each adaptor can apply its own defaults.
+func toProviderOptions(opts *Options) provider.GenerationOptions {
+	if opts == nil {
+		return provider.GenerationOptions{}
+	}
+	out := provider.GenerationOptions{
+		Temperature:     opts.Temperature,
+		TopK:            opts.TopK,
+		TopP:            opts.TopP,
+		MaxOutputTokens: opts.MaxOutputTokens,
+		StopSequences:   opts.StopSequences,
+	}
+	for _, s := range opts.SafetySettings {
+		out.SafetySettings = append(out.SafetySettings, provider.SafetySetting{Category: s.Category, Threshold: s.Threshold})
+	}
+	return out
+}
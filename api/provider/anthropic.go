@@ -0,0 +1,273 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// anthropicChatModel is the Claude model used for Complete/Stream.
+const anthropicChatModel = "claude-3-5-sonnet-20241022"
+
+const anthropicAPIVersion = "2023-06-01"
+
+// AnthropicClient implements ChatCompletionClient against Anthropic's
+// Messages API. It has no embeddings endpoint, so Embed always errors.
+type AnthropicClient struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewAnthropicClient returns an AnthropicClient for the given API key.
+func NewAnthropicClient(apiKey string) *AnthropicClient {
+	return &AnthropicClient{apiKey: apiKey, httpClient: &http.Client{}}
+}
+
+// anthropicMessage's Content is either a plain string (the common case, a
+// text-only turn) or a []anthropicContentBlock when the message carries
+// attachments, matching the two shapes the Messages API accepts.
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+// anthropicContentBlock is one block of a multi-part message: either a text
+// block or an inline base64 image, per Anthropic's content block format.
+type anthropicContentBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float32           `json:"temperature,omitempty"`
+	TopP          *float32           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason,omitempty"`
+}
+
+// anthropicMaxTokens mirrors Gemini's MaxOutputTokens default so replies are
+// comparable in length across providers.
+const anthropicMaxTokens = 8192
+
+func toAnthropicMessages(messages []Message) ([]anthropicMessage, error) {
+	var out []anthropicMessage
+	for _, msg := range messages {
+		if msg.Text == "" && len(msg.Attachments) == 0 {
+			continue
+		}
+		role := "assistant"
+		if msg.Role == "user" {
+			role = "user"
+		}
+
+		if len(msg.Attachments) == 0 {
+			out = append(out, anthropicMessage{Role: role, Content: msg.Text})
+			continue
+		}
+
+		var blocks []anthropicContentBlock
+		if msg.Text != "" {
+			blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Text})
+		}
+		for _, att := range msg.Attachments {
+			if att.Data == "" {
+				return nil, &InvalidRequestError{Message: "anthropic provider only supports inline attachments, not a fileUri reference"}
+			}
+			blocks = append(blocks, anthropicContentBlock{
+				Type:   "image",
+				Source: &anthropicImageSource{Type: "base64", MediaType: att.MimeType, Data: att.Data},
+			})
+		}
+		out = append(out, anthropicMessage{Role: role, Content: blocks})
+	}
+	if len(out) == 0 {
+		return nil, &InvalidRequestError{Message: "no valid messages to send"}
+	}
+	if out[len(out)-1].Role != "user" {
+		return nil, &InvalidRequestError{Message: "last message must be from user"}
+	}
+	return out, nil
+}
+
+// anthropicMaxTokensFromOptions returns opts.MaxOutputTokens if set, falling
+// back to anthropicMaxTokens otherwise.
+func anthropicMaxTokensFromOptions(opts GenerationOptions) int {
+	if opts.MaxOutputTokens != nil {
+		return *opts.MaxOutputTokens
+	}
+	return anthropicMaxTokens
+}
+
+func (c *AnthropicClient) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	messages, err := toAnthropicMessages(req.Messages)
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+
+	reqBody := anthropicRequest{
+		Model:         anthropicChatModel,
+		System:        req.SystemInstruction,
+		Messages:      messages,
+		MaxTokens:     anthropicMaxTokensFromOptions(req.Options),
+		Temperature:   req.Options.Temperature,
+		TopP:          req.Options.TopP,
+		StopSequences: req.Options.StopSequences,
+	}
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to make request to Anthropic API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Anthropic API error response: %s", string(bodyBytes))
+		return CompletionResponse{}, newUpstreamError(resp, bodyBytes)
+	}
+
+	var anthropicResp anthropicResponse
+	if err := json.Unmarshal(bodyBytes, &anthropicResp); err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	var text string
+	for _, block := range anthropicResp.Content {
+		if block.Type == "text" {
+			text += block.Text
+		}
+	}
+	return CompletionResponse{Text: text, FinishReason: anthropicResp.StopReason}, nil
+}
+
+// anthropicStreamEvent is the subset of Anthropic's SSE event payloads we
+// care about: incremental text deltas.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Text string `json:"text"`
+	} `json:"delta"`
+}
+
+func (c *AnthropicClient) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	messages, err := toAnthropicMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody := anthropicRequest{
+		Model:         anthropicChatModel,
+		System:        req.SystemInstruction,
+		Messages:      messages,
+		MaxTokens:     anthropicMaxTokensFromOptions(req.Options),
+		Temperature:   req.Options.Temperature,
+		TopP:          req.Options.TopP,
+		StopSequences: req.Options.StopSequences,
+		Stream:        true,
+	}
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Anthropic API: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		bodyBytes, _ := bufio.NewReader(resp.Body).Peek(512)
+		return nil, newUpstreamError(resp, bodyBytes)
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if ctx.Err() != nil {
+				return
+			}
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			data := strings.TrimPrefix(line, "data: ")
+
+			var event anthropicStreamEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				continue
+			}
+			if event.Type == "content_block_delta" && event.Delta.Text != "" {
+				select {
+				case out <- StreamChunk{Text: event.Delta.Text}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			select {
+			case out <- StreamChunk{Err: fmt.Errorf("error reading Anthropic stream: %v", err)}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Embed is unsupported: Anthropic does not offer an embeddings API.
+func (c *AnthropicClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, fmt.Errorf("anthropic provider does not support embeddings")
+}
@@ -0,0 +1,418 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"ai-chat-project/embeddings"
+	"ai-chat-project/tools"
+)
+
+// geminiEmbeddingModel is the Gemini model used for Embed.
+const geminiEmbeddingModel = "text-embedding-004"
+
+// geminiChatModel is the Gemini model used for Complete/Stream.
+const geminiChatModel = "gemini-2.5-flash"
+
+// maxToolIterations caps how many functionCall/functionResponse round-trips
+// Complete will make for a single request, so a misbehaving tool or model
+// can't loop forever.
+const maxToolIterations = 5
+
+// GeminiClient implements ChatCompletionClient against Google's Gemini API,
+// including function-calling against a tools.Registry.
+type GeminiClient struct {
+	apiKey     string
+	tools      *tools.Registry
+	httpClient *http.Client
+}
+
+// NewGeminiClient returns a GeminiClient with the built-in reference tools
+// (HTTP fetch, current time) registered for function calling.
+func NewGeminiClient(apiKey string) *GeminiClient {
+	registry := tools.NewRegistry()
+	registry.Register(tools.HTTPFetch{})
+	registry.Register(tools.CurrentTime{})
+	return &GeminiClient{apiKey: apiKey, tools: registry, httpClient: &http.Client{}}
+}
+
+// --- Gemini wire format ---
+
+type geminiRequest struct {
+	Contents          []geminiContent       `json:"contents"`
+	SystemInstruction *geminiContent        `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool          `json:"tools,omitempty"`
+	GenerationConfig  geminiGenConfig       `json:"generationConfig"`
+	SafetySettings    []geminiSafetySetting `json:"safetySettings,omitempty"`
+}
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *geminiInlineData       `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+type geminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+type geminiFileData struct {
+	MimeType string `json:"mimeType"`
+	FileURI  string `json:"fileUri"`
+}
+type geminiFunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+type geminiFunctionResponse struct {
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+type geminiTool struct {
+	FunctionDeclarations []tools.FunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+type geminiGenConfig struct {
+	Temperature     float32  `json:"temperature"`
+	TopK            int      `json:"topK"`
+	TopP            float32  `json:"topP"`
+	MaxOutputTokens int      `json:"maxOutputTokens"`
+	StopSequences   []string `json:"stopSequences"`
+}
+type geminiSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []geminiPart `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason,omitempty"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason,omitempty"`
+	} `json:"promptFeedback,omitempty"`
+}
+
+func defaultGenConfig() geminiGenConfig {
+	return geminiGenConfig{
+		Temperature:     1.0,
+		TopK:            40,
+		TopP:            0.95,
+		MaxOutputTokens: 8192,
+		StopSequences:   []string{},
+	}
+}
+
+// genConfigFromOptions merges caller-supplied GenerationOptions onto the
+// defaults, leaving any unset (nil) field at its default value.
+func genConfigFromOptions(opts GenerationOptions) geminiGenConfig {
+	cfg := defaultGenConfig()
+	if opts.Temperature != nil {
+		cfg.Temperature = *opts.Temperature
+	}
+	if opts.TopK != nil {
+		cfg.TopK = *opts.TopK
+	}
+	if opts.TopP != nil {
+		cfg.TopP = *opts.TopP
+	}
+	if opts.MaxOutputTokens != nil {
+		cfg.MaxOutputTokens = *opts.MaxOutputTokens
+	}
+	if opts.StopSequences != nil {
+		cfg.StopSequences = opts.StopSequences
+	}
+	return cfg
+}
+
+func safetySettingsFromOptions(opts GenerationOptions) []geminiSafetySetting {
+	if len(opts.SafetySettings) == 0 {
+		return nil
+	}
+	out := make([]geminiSafetySetting, len(opts.SafetySettings))
+	for i, s := range opts.SafetySettings {
+		out[i] = geminiSafetySetting{Category: s.Category, Threshold: s.Threshold}
+	}
+	return out
+}
+
+// buildContents converts provider-agnostic messages into Gemini's content
+// format, validating that there's at least one message and that the last is
+// from the user (as Gemini requires).
+func buildContents(messages []Message) ([]geminiContent, error) {
+	var contents []geminiContent
+
+	for _, msg := range messages {
+		if msg.Text == "" && len(msg.Attachments) == 0 {
+			continue
+		}
+
+		role := "model"
+		if msg.Role == "user" {
+			role = "user"
+		}
+
+		var parts []geminiPart
+		if msg.Text != "" {
+			parts = append(parts, geminiPart{Text: msg.Text})
+		}
+		for _, att := range msg.Attachments {
+			parts = append(parts, attachmentToPart(att))
+		}
+
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+
+	if len(contents) == 0 {
+		return nil, &InvalidRequestError{Message: "no valid messages to send"}
+	}
+	if contents[len(contents)-1].Role != "user" {
+		return nil, &InvalidRequestError{Message: "last message must be from user"}
+	}
+	return contents, nil
+}
+
+func attachmentToPart(att Attachment) geminiPart {
+	if att.FileURI != "" {
+		return geminiPart{FileData: &geminiFileData{MimeType: att.MimeType, FileURI: att.FileURI}}
+	}
+	return geminiPart{InlineData: &geminiInlineData{MimeType: att.MimeType, Data: att.Data}}
+}
+
+func systemInstructionContent(sysInstruction string) *geminiContent {
+	if sysInstruction == "" {
+		return nil
+	}
+	return &geminiContent{Parts: []geminiPart{{Text: sysInstruction}}}
+}
+
+// Complete implements ChatCompletionClient. It loops on functionCall
+// responses, dispatching to the tool registry and feeding results back,
+// until the model returns plain text or maxToolIterations is hit.
+func (c *GeminiClient) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	contents, err := buildContents(req.Messages)
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+	sysInstruction := systemInstructionContent(req.SystemInstruction)
+
+	for iteration := 0; ; iteration++ {
+		if iteration >= maxToolIterations {
+			return CompletionResponse{}, fmt.Errorf("exceeded max tool-call iterations (%d)", maxToolIterations)
+		}
+
+		resp, err := c.send(ctx, contents, sysInstruction, req.Options)
+		if err != nil {
+			return CompletionResponse{}, err
+		}
+
+		if len(resp.Candidates) == 0 {
+			blockReason := ""
+			if resp.PromptFeedback != nil {
+				blockReason = resp.PromptFeedback.BlockReason
+			}
+			log.Printf("Empty or blocked response from Gemini: %+v", resp)
+			return CompletionResponse{BlockReason: blockReason}, nil
+		}
+
+		candidate := resp.Candidates[0]
+		if candidate.FinishReason == "SAFETY" {
+			return CompletionResponse{FinishReason: candidate.FinishReason}, nil
+		}
+		if len(candidate.Content.Parts) == 0 {
+			log.Printf("Empty response from Gemini: %+v", resp)
+			return CompletionResponse{FinishReason: candidate.FinishReason}, nil
+		}
+
+		parts := candidate.Content.Parts
+
+		var calls []geminiPart
+		var text string
+		for _, p := range parts {
+			if p.FunctionCall != nil {
+				calls = append(calls, p)
+			} else {
+				text += p.Text
+			}
+		}
+
+		if len(calls) == 0 {
+			return CompletionResponse{Text: text, FinishReason: candidate.FinishReason}, nil
+		}
+
+		contents = append(contents, geminiContent{Role: "model", Parts: calls})
+
+		var responseParts []geminiPart
+		for _, call := range calls {
+			responseParts = append(responseParts, c.invokeTool(ctx, call.FunctionCall))
+		}
+		contents = append(contents, geminiContent{Role: "function", Parts: responseParts})
+	}
+}
+
+func (c *GeminiClient) invokeTool(ctx context.Context, call *geminiFunctionCall) geminiPart {
+	result, err := c.tools.Invoke(ctx, call.Name, call.Args)
+	if err != nil {
+		log.Printf("WARN: tool %q failed: %v", call.Name, err)
+		result = map[string]string{"error": err.Error()}
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		response = []byte(fmt.Sprintf(`{"error": %q}`, err.Error()))
+	}
+
+	return geminiPart{FunctionResponse: &geminiFunctionResponse{Name: call.Name, Response: response}}
+}
+
+func (c *GeminiClient) send(ctx context.Context, contents []geminiContent, sysInstruction *geminiContent, opts GenerationOptions) (geminiResponse, error) {
+	apiURL := "https://generativelanguage.googleapis.com/v1beta/models/" + geminiChatModel + ":generateContent?key=" + c.apiKey
+
+	reqBody := geminiRequest{
+		Contents:          contents,
+		SystemInstruction: sysInstruction,
+		Tools:             []geminiTool{{FunctionDeclarations: c.tools.FunctionDeclarations()}},
+		GenerationConfig:  genConfigFromOptions(opts),
+		SafetySettings:    safetySettingsFromOptions(opts),
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return geminiResponse{}, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return geminiResponse{}, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return geminiResponse{}, fmt.Errorf("failed to make request to Gemini API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return geminiResponse{}, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Gemini API error response: %s", string(bodyBytes))
+		return geminiResponse{}, newUpstreamError(resp, bodyBytes)
+	}
+
+	var geminiResp geminiResponse
+	if err := json.Unmarshal(bodyBytes, &geminiResp); err != nil {
+		log.Printf("Failed to decode Gemini response: %v, body: %s", err, string(bodyBytes))
+		return geminiResponse{}, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return geminiResp, nil
+}
+
+// Stream implements ChatCompletionClient by calling streamGenerateContent
+// and converting each SSE frame into a StreamChunk. Tool calling is not
+// supported in streaming mode, matching the upstream API's own limitation.
+func (c *GeminiClient) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	contents, err := buildContents(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	sysInstruction := systemInstructionContent(req.SystemInstruction)
+
+	reqBody := geminiRequest{
+		Contents:          contents,
+		SystemInstruction: sysInstruction,
+		GenerationConfig:  genConfigFromOptions(req.Options),
+		SafetySettings:    safetySettingsFromOptions(req.Options),
+	}
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	apiURL := "https://generativelanguage.googleapis.com/v1beta/models/" + geminiChatModel + ":streamGenerateContent?alt=sse&key=" + c.apiKey
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Gemini API: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		bodyBytes, _ := bufio.NewReader(resp.Body).Peek(512)
+		return nil, newUpstreamError(resp, bodyBytes)
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			data := strings.TrimPrefix(line, "data: ")
+
+			var chunk geminiResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				log.Printf("WARN: failed to decode Gemini stream chunk: %v, chunk: %s", err, data)
+				continue
+			}
+			if len(chunk.Candidates) == 0 || len(chunk.Candidates[0].Content.Parts) == 0 {
+				continue
+			}
+			select {
+			case out <- StreamChunk{Text: chunk.Candidates[0].Content.Parts[0].Text}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			select {
+			case out <- StreamChunk{Err: fmt.Errorf("error reading Gemini stream: %v", err)}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Embed implements ChatCompletionClient using Gemini's batchEmbedContents action.
+func (c *GeminiClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := embeddings.EmbedTexts(ctx, c.apiKey, geminiEmbeddingModel, texts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]float32, len(vectors))
+	for i, v := range vectors {
+		out[i] = []float32(v)
+	}
+	return out, nil
+}
@@ -0,0 +1,306 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// openAIChatModel is the model used for Complete/Stream.
+const openAIChatModel = "gpt-4o"
+
+// openAIEmbeddingModel is the model used for Embed.
+const openAIEmbeddingModel = "text-embedding-3-small"
+
+// OpenAIClient implements ChatCompletionClient against OpenAI's chat
+// completions and embeddings APIs.
+type OpenAIClient struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpenAIClient returns an OpenAIClient for the given API key.
+func NewOpenAIClient(apiKey string) *OpenAIClient {
+	return &OpenAIClient{apiKey: apiKey, httpClient: &http.Client{}}
+}
+
+// openAIMessage's Content is either a plain string (the common case, a
+// text-only turn) or a []openAIContentPart when the message carries
+// attachments, matching the two shapes the chat completions API accepts.
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+// openAIContentPart is one part of a multi-part message: either a text part
+// or an image referenced by URL (we pass a data: URI for inline attachments).
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIChatRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature *float32        `json:"temperature,omitempty"`
+	TopP        *float32        `json:"top_p,omitempty"`
+	MaxTokens   *int            `json:"max_tokens,omitempty"`
+	Stop        []string        `json:"stop,omitempty"`
+	Stream      bool            `json:"stream,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason,omitempty"`
+	} `json:"choices"`
+}
+
+type openAIChatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+func toOpenAIMessages(messages []Message, sysInstruction string) ([]openAIMessage, error) {
+	var out []openAIMessage
+	if sysInstruction != "" {
+		out = append(out, openAIMessage{Role: "system", Content: sysInstruction})
+	}
+	for _, msg := range messages {
+		if msg.Text == "" && len(msg.Attachments) == 0 {
+			continue
+		}
+		role := "assistant"
+		if msg.Role == "user" {
+			role = "user"
+		}
+
+		if len(msg.Attachments) == 0 {
+			out = append(out, openAIMessage{Role: role, Content: msg.Text})
+			continue
+		}
+
+		var parts []openAIContentPart
+		if msg.Text != "" {
+			parts = append(parts, openAIContentPart{Type: "text", Text: msg.Text})
+		}
+		for _, att := range msg.Attachments {
+			if att.Data == "" {
+				return nil, &InvalidRequestError{Message: "openai provider only supports inline attachments, not a fileUri reference"}
+			}
+			parts = append(parts, openAIContentPart{
+				Type:     "image_url",
+				ImageURL: &openAIImageURL{URL: fmt.Sprintf("data:%s;base64,%s", att.MimeType, att.Data)},
+			})
+		}
+		out = append(out, openAIMessage{Role: role, Content: parts})
+	}
+	if len(out) == 0 || out[len(out)-1].Role != "user" {
+		return nil, &InvalidRequestError{Message: "last message must be from user"}
+	}
+	return out, nil
+}
+
+func (c *OpenAIClient) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	messages, err := toOpenAIMessages(req.Messages, req.SystemInstruction)
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+
+	reqBody := openAIChatRequest{
+		Model:       openAIChatModel,
+		Messages:    messages,
+		Temperature: req.Options.Temperature,
+		TopP:        req.Options.TopP,
+		MaxTokens:   req.Options.MaxOutputTokens,
+		Stop:        req.Options.StopSequences,
+	}
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to make request to OpenAI API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("OpenAI API error response: %s", string(bodyBytes))
+		return CompletionResponse{}, newUpstreamError(resp, bodyBytes)
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.Unmarshal(bodyBytes, &chatResp); err != nil {
+		return CompletionResponse{}, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return CompletionResponse{}, nil
+	}
+	choice := chatResp.Choices[0]
+	return CompletionResponse{Text: choice.Message.Content, FinishReason: choice.FinishReason}, nil
+}
+
+func (c *OpenAIClient) Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error) {
+	messages, err := toOpenAIMessages(req.Messages, req.SystemInstruction)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody := openAIChatRequest{
+		Model:       openAIChatModel,
+		Messages:    messages,
+		Temperature: req.Options.Temperature,
+		TopP:        req.Options.TopP,
+		MaxTokens:   req.Options.MaxOutputTokens,
+		Stop:        req.Options.StopSequences,
+		Stream:      true,
+	}
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to OpenAI API: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		bodyBytes, _ := bufio.NewReader(resp.Body).Peek(512)
+		return nil, newUpstreamError(resp, bodyBytes)
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if ctx.Err() != nil {
+				return
+			}
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			data := strings.TrimPrefix(line, "data: ")
+			if data == "[DONE]" {
+				return
+			}
+
+			var chunk openAIChatStreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+				continue
+			}
+			select {
+			case out <- StreamChunk{Text: chunk.Choices[0].Delta.Content}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			select {
+			case out <- StreamChunk{Err: fmt.Errorf("error reading OpenAI stream: %v", err)}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+type openAIEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed implements ChatCompletionClient using OpenAI's embeddings API.
+func (c *OpenAIClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	reqBody := openAIEmbeddingRequest{Model: openAIEmbeddingModel, Input: texts}
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to OpenAI API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newUpstreamError(resp, bodyBytes)
+	}
+
+	var embedResp openAIEmbeddingResponse
+	if err := json.Unmarshal(bodyBytes, &embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	out := make([][]float32, len(embedResp.Data))
+	for i, d := range embedResp.Data {
+		out[i] = d.Embedding
+	}
+	return out, nil
+}
@@ -0,0 +1,125 @@
+// Package provider abstracts away the differences between the chat
+// completion APIs of Gemini, Anthropic, and OpenAI behind a single
+// ChatCompletionClient interface, so the handler package can stay
+// provider-agnostic and select an adaptor per request.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// UpstreamError wraps a non-2xx response from a provider's API so callers
+// can distinguish, say, a 429 (which should be retried) from a 502-worthy
+// failure, and forward any Retry-After hint the provider gave us.
+type UpstreamError struct {
+	StatusCode int
+	RetryAfter int // seconds, 0 if the provider didn't say
+	Body       string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("upstream API error (%d): %s", e.StatusCode, e.Body)
+}
+
+// newUpstreamError builds an UpstreamError from a non-2xx response,
+// forwarding any Retry-After header the upstream API set.
+func newUpstreamError(resp *http.Response, body []byte) *UpstreamError {
+	retryAfter := 0
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			retryAfter = parsed
+		}
+	}
+	return &UpstreamError{StatusCode: resp.StatusCode, RetryAfter: retryAfter, Body: string(body)}
+}
+
+// InvalidRequestError indicates the request itself was malformed in a way
+// caught before any call to the provider's API went out - e.g. no message
+// from the user, or an attachment shape this provider can't handle. Callers
+// (the handler package) should map this to a 400, distinct from
+// UpstreamError's genuine upstream-API failures.
+type InvalidRequestError struct {
+	Message string
+}
+
+func (e *InvalidRequestError) Error() string {
+	return e.Message
+}
+
+// Message is a single turn in a conversation, in provider-agnostic form.
+// Role is always "user" or "assistant"; each adaptor maps that onto its own
+// wire format (e.g. Gemini's "model", or a system-prompt placement).
+type Message struct {
+	Role        string
+	Text        string
+	Attachments []Attachment
+}
+
+// Attachment is an image/file part of a Message. Exactly one of Data or
+// FileURI should be set: Data for small inline base64 payloads, FileURI for
+// files already uploaded to a provider-specific file store.
+type Attachment struct {
+	MimeType string
+	Data     string
+	FileURI  string
+}
+
+// CompletionRequest is what Handler passes to a ChatCompletionClient. Each
+// adaptor translates it into its own shape; fields a provider doesn't
+// support (e.g. SafetySettings outside Gemini) are silently ignored.
+type CompletionRequest struct {
+	Messages          []Message
+	SystemInstruction string
+	Options           GenerationOptions
+}
+
+// GenerationOptions are the generation-time knobs exposed per request. A nil
+// pointer field means "use the provider's default"; validation of supplied
+// values happens in the handler package before a CompletionRequest is built.
+type GenerationOptions struct {
+	Temperature     *float32
+	TopK            *int
+	TopP            *float32
+	MaxOutputTokens *int
+	StopSequences   []string
+	// SafetySettings is Gemini-specific; other adaptors ignore it.
+	SafetySettings []SafetySetting
+}
+
+// SafetySetting is one Gemini harm-category/threshold pair, e.g.
+// {Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_ONLY_HIGH"}.
+type SafetySetting struct {
+	Category  string
+	Threshold string
+}
+
+// CompletionResponse is a completed, non-streaming reply.
+type CompletionResponse struct {
+	Text string
+	// FinishReason mirrors the upstream API's own terminology (e.g. Gemini's
+	// "STOP"/"SAFETY"/"MAX_TOKENS"), empty if the provider doesn't report one.
+	FinishReason string
+	// BlockReason is set when the prompt itself was blocked before the model
+	// generated any candidates (Gemini's promptFeedback.blockReason).
+	BlockReason string
+}
+
+// StreamChunk is one incremental delta of a streamed reply. Err is set on
+// the terminal chunk if the stream ended in failure; the channel is always
+// closed after the terminal chunk (error or not).
+type StreamChunk struct {
+	Text string
+	Err  error
+}
+
+// ChatCompletionClient is implemented by each provider adaptor (Gemini,
+// Anthropic, OpenAI, ...). Handler selects one per request based on the
+// requested model and talks to it only through this interface.
+type ChatCompletionClient interface {
+	Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error)
+	Stream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error)
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRateLimitRPS and defaultRateLimitBurst are used when the
+// RATE_LIMIT_RPS / RATE_LIMIT_BURST env vars aren't set.
+const (
+	defaultRateLimitRPS   = 1.0
+	defaultRateLimitBurst = 5
+)
+
+// bucketTTL is how long an idle bucket is kept before eviction. A client
+// that hasn't made a request in this long is indistinguishable from one
+// we've never seen, so there's no reason to keep its entry around.
+const bucketTTL = 10 * time.Minute
+
+// bucketSweepInterval bounds how often Allow pays the cost of scanning the
+// whole bucket map for eviction, instead of doing it on every call.
+const bucketSweepInterval = 1 * time.Minute
+
+// rateLimiter is a per-IP token bucket, shared across requests handled by
+// this process. Each serverless instance gets its own bucket set, which is
+// good enough to blunt abusive bursts without needing shared state.
+var rateLimiter = newRateLimiter(rateLimitConfigFromEnv)
+
+// bucket is a single client's token bucket.
+type bucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// rateLimiterConfig lets RPS/burst be read fresh from the environment on
+// every Allow call, so tests (and ops) can tune limits without a restart.
+type rateLimiterConfig func() (rps float64, burst int)
+
+func rateLimitConfigFromEnv() (float64, int) {
+	rps := defaultRateLimitRPS
+	if v := os.Getenv("RATE_LIMIT_RPS"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 {
+			rps = parsed
+		}
+	}
+	burst := defaultRateLimitBurst
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			burst = parsed
+		}
+	}
+	return rps, burst
+}
+
+// RateLimiter is a map of per-key token buckets.
+type RateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	config    rateLimiterConfig
+	lastSweep time.Time
+}
+
+func newRateLimiter(config rateLimiterConfig) *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*bucket), config: config, lastSweep: time.Now()}
+}
+
+// Allow reports whether a request from key (typically a client IP) should
+// proceed, consuming one token if so.
+func (rl *RateLimiter) Allow(key string) bool {
+	rps, burst := rl.config()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.evictStale(now)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst) - 1, lastRefill: now}
+		rl.buckets[key] = b
+		return true
+	}
+
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.tokens += elapsed * rps
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// evictStale drops buckets idle for longer than bucketTTL, so a spoofed or
+// one-off client IP doesn't grow the map forever. Callers must hold rl.mu.
+// The scan itself only runs once per bucketSweepInterval.
+func (rl *RateLimiter) evictStale(now time.Time) {
+	if now.Sub(rl.lastSweep) < bucketSweepInterval {
+		return
+	}
+	rl.lastSweep = now
+	for key, b := range rl.buckets {
+		if now.Sub(b.lastRefill) > bucketTTL {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// trustedProxyHops is how many entries at the right end of X-Forwarded-For
+// were appended by proxies we trust (e.g. a single load balancer in front of
+// this function). Defaults to 0: X-Forwarded-For is attacker-controlled
+// input, so with no trusted proxy configured we rate-limit on RemoteAddr
+// instead of letting a caller pick a fresh IP per request to dodge the limit.
+func trustedProxyHops() int {
+	v := os.Getenv("TRUSTED_PROXY_HOPS")
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// clientIP extracts the caller's IP for rate-limiting. With TRUSTED_PROXY_HOPS
+// unset (the default), it's just r.RemoteAddr: X-Forwarded-For is supplied
+// by the client and trivially spoofable, so trusting it with no proxy in
+// front of us would let a caller bypass the limiter by sending a new value
+// per request. With TRUSTED_PROXY_HOPS set to N, the Nth-from-the-right
+// entry of X-Forwarded-For is used instead, since only the hops appended by
+// our own trusted proxies are reliable.
+func clientIP(r *http.Request) string {
+	hops := trustedProxyHops()
+	if hops <= 0 {
+		return r.RemoteAddr
+	}
+
+	xff := r.Header.Get("X-Forwarded-For")
+	if xff == "" {
+		return r.RemoteAddr
+	}
+
+	parts := strings.Split(xff, ",")
+	idx := len(parts) - hops
+	if idx < 0 || idx >= len(parts) {
+		return r.RemoteAddr
+	}
+	ip := strings.TrimSpace(parts[idx])
+	if ip == "" {
+		return r.RemoteAddr
+	}
+	return ip
+}
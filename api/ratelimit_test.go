@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func testConfig(rps float64, burst int) rateLimiterConfig {
+	return func() (float64, int) { return rps, burst }
+}
+
+func TestRateLimiterAllowBurstThenBlocks(t *testing.T) {
+	rl := newRateLimiter(testConfig(1, 3))
+
+	for i := 0; i < 3; i++ {
+		if !rl.Allow("client-a") {
+			t.Fatalf("expected request %d within burst to be allowed", i)
+		}
+	}
+	if rl.Allow("client-a") {
+		t.Fatal("expected request beyond burst to be blocked")
+	}
+}
+
+func TestRateLimiterTracksKeysIndependently(t *testing.T) {
+	rl := newRateLimiter(testConfig(1, 1))
+
+	if !rl.Allow("client-a") {
+		t.Fatal("expected first request from client-a to be allowed")
+	}
+	if !rl.Allow("client-b") {
+		t.Fatal("expected first request from client-b to be allowed, independent of client-a's bucket")
+	}
+}
+
+func TestRateLimiterEvictStaleBuckets(t *testing.T) {
+	rl := newRateLimiter(testConfig(1, 1))
+	rl.Allow("stale-client")
+
+	rl.mu.Lock()
+	rl.buckets["stale-client"].lastRefill = time.Now().Add(-2 * bucketTTL)
+	rl.lastSweep = time.Now().Add(-2 * bucketSweepInterval)
+	rl.mu.Unlock()
+
+	rl.Allow("fresh-client")
+
+	rl.mu.Lock()
+	_, stillPresent := rl.buckets["stale-client"]
+	rl.mu.Unlock()
+
+	if stillPresent {
+		t.Fatal("expected stale bucket to be evicted on the next sweep")
+	}
+}
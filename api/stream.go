@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"ai-chat-project/provider"
+)
+
+// streamToClient drives a provider's Stream and re-emits each delta to w as
+// its own SSE event, finishing with a "done" event. Each event carries an
+// incrementing id so a client can resume with Last-Event-ID after a
+// reconnect. If ctx is cancelled (the client disconnected), iteration stops
+// as soon as the next chunk would otherwise be written.
+func streamToClient(ctx context.Context, w http.ResponseWriter, client provider.ChatCompletionClient, req provider.CompletionRequest) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming_unsupported", "streaming unsupported by response writer", 0)
+		return fmt.Errorf("streaming unsupported by response writer")
+	}
+
+	chunks, err := client.Stream(ctx, req)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	eventID := 0
+	for chunk := range chunks {
+		if ctx.Err() != nil {
+			return nil // client disconnected
+		}
+		if chunk.Err != nil {
+			log.Printf("ERROR: error reading provider stream: %v", chunk.Err)
+			break
+		}
+
+		eventID++
+		payload, _ := json.Marshal(ChatResponse{Reply: chunk.Text})
+		fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", eventID, payload)
+		flusher.Flush()
+	}
+
+	eventID++
+	fmt.Fprintf(w, "id: %d\nevent: done\ndata: {}\n\n", eventID)
+	flusher.Flush()
+	return nil
+}
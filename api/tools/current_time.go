@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// CurrentTime is a reference tool that returns the current time in UTC. It
+// takes no arguments.
+type CurrentTime struct{}
+
+func (CurrentTime) Name() string        { return "current_time" }
+func (CurrentTime) Description() string { return "Returns the current date and time in UTC." }
+
+func (CurrentTime) Schema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{}}`)
+}
+
+func (CurrentTime) Invoke(ctx context.Context, args json.RawMessage) (any, error) {
+	return map[string]string{"now": time.Now().UTC().Format(time.RFC3339)}, nil
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// httpFetchMaxBytes caps how much of a fetched page we hand back to the
+// model, so a huge response doesn't blow out the context window.
+const httpFetchMaxBytes = 64 * 1024
+
+// HTTPFetch is a reference tool that fetches a URL over HTTP(S) and returns
+// its body as text. Requests to loopback, link-local, private, and cloud
+// metadata addresses are refused (see isBlockedIP), since the target URL
+// is attacker-steerable through the model's tool call.
+type HTTPFetch struct{}
+
+func (HTTPFetch) Name() string { return "http_fetch" }
+func (HTTPFetch) Description() string {
+	return "Fetches the contents of a public HTTP(S) URL and returns the response body as text."
+}
+
+func (HTTPFetch) Schema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"url": {"type": "string", "description": "The URL to fetch, including scheme."}
+		},
+		"required": ["url"]
+	}`)
+}
+
+func (HTTPFetch) Invoke(ctx context.Context, args json.RawMessage) (any, error) {
+	var params struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	if params.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", params.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %v", err)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", req.URL.Scheme)
+	}
+
+	client := &http.Client{
+		// Check every IP the dialer actually connects to - including
+		// redirect targets and DNS answers for the original host - so a
+		// hostname that resolves to a blocked address (DNS rebinding, or
+		// just a public name pointing at 169.254.169.254) can't slip
+		// through a literal-host check done only once up front.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("unsupported redirect URL scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+		Transport: &http.Transport{
+			DialContext: safeDialContext,
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch url: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpFetchMaxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	return map[string]any{
+		"status": resp.StatusCode,
+		"body":   string(body),
+	}, nil
+}
+
+// safeDialContext is a net.Dialer.DialContext that refuses to connect to any
+// address resolving to a loopback, link-local, private, or cloud metadata
+// IP, blocking the actual connect target rather than the literal hostname so
+// DNS rebinding can't bypass it.
+func safeDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ip := range ips {
+		if isBlockedIP(ip.IP) {
+			return nil, fmt.Errorf("refusing to fetch blocked address %s", ip.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("could not resolve host %q", host)
+	}
+
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// isBlockedIP reports whether ip is a loopback, link-local (including the
+// 169.254.169.254 cloud metadata address), unspecified (0.0.0.0 or ::),
+// RFC1918 private, or fc00::/7 unique local address.
+func isBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, block := range privateCIDRs {
+		if block.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var privateCIDRs = mustParseCIDRs(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
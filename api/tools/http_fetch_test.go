@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestIsBlockedIP(t *testing.T) {
+	cases := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"169.254.169.254", true}, // cloud metadata
+		{"10.0.0.5", true},
+		{"172.16.3.4", true},
+		{"192.168.1.1", true},
+		{"0.0.0.0", true},
+		{"8.8.8.8", false},
+		{"93.184.216.34", false},
+	}
+
+	for _, tc := range cases {
+		ip := net.ParseIP(tc.ip)
+		if ip == nil {
+			t.Fatalf("failed to parse test IP %q", tc.ip)
+		}
+		if got := isBlockedIP(ip); got != tc.blocked {
+			t.Errorf("isBlockedIP(%s) = %v, want %v", tc.ip, got, tc.blocked)
+		}
+	}
+}
+
+func TestSafeDialContextRefusesBlockedAddr(t *testing.T) {
+	if _, err := safeDialContext(context.Background(), "tcp", "169.254.169.254:80"); err == nil {
+		t.Fatal("expected safeDialContext to refuse the cloud metadata address, got nil error")
+	}
+}
@@ -0,0 +1,83 @@
+// Package tools provides a pluggable registry of function-calling tools
+// that the Gemini bridge in the provider package can dispatch to when the
+// model emits a functionCall part.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Tool is a single callable function exposed to the model. Args is the
+// model-supplied JSON object matching Schema(); Invoke's return value is
+// marshalled back to Gemini as the functionResponse payload.
+type Tool interface {
+	Name() string
+	Description() string
+	Schema() json.RawMessage
+	Invoke(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+// FunctionDeclaration is the wire format Gemini expects inside
+// tools[].functionDeclarations when advertising available tools.
+type FunctionDeclaration struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// Registry holds the tools available for a given request. It's safe for
+// concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
+}
+
+// Register adds t to the registry, replacing any existing tool of the same name.
+func (r *Registry) Register(t Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[t.Name()] = t
+}
+
+// Get looks up a tool by name.
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// Invoke looks up name and invokes it with args, returning an error if no
+// such tool is registered.
+func (r *Registry) Invoke(ctx context.Context, name string, args json.RawMessage) (any, error) {
+	t, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("no tool registered with name %q", name)
+	}
+	return t.Invoke(ctx, args)
+}
+
+// FunctionDeclarations returns the Gemini tool declarations for every
+// registered tool, for use in a Gemini request's tools field.
+func (r *Registry) FunctionDeclarations() []FunctionDeclaration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	decls := make([]FunctionDeclaration, 0, len(r.tools))
+	for _, t := range r.tools {
+		decls = append(decls, FunctionDeclaration{
+			Name:        t.Name(),
+			Description: t.Description(),
+			Parameters:  t.Schema(),
+		})
+	}
+	return decls
+}